@@ -0,0 +1,221 @@
+package nslookup
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// negativeTTL is how long a lookup that returned no answer and no
+// delegation (ErrNotFound) is remembered, to avoid hammering the same
+// broken name over and over.
+const negativeTTL = 30 * time.Second
+
+// CacheKey identifies a cached answer by normalized owner name and
+// query type. Name is lower-cased and trailing-dotted, e.g. "example.com.".
+type CacheKey struct {
+	Name string
+	Type dnsmessage.Type
+}
+
+// CacheEntry is what a Cache stores for a CacheKey.
+type CacheEntry struct {
+	Answers     []dnsmessage.Resource
+	Authorities []dnsmessage.Resource
+	Additionals []dnsmessage.Resource
+	Expires     time.Time
+	// Negative marks an entry as a cached ErrNotFound, so Get can
+	// return it without the caller confusing it with a real answer.
+	Negative bool
+	// Authenticated is true when the Answers RRset passed DNSSEC
+	// validation against the Resolver's trust anchors.
+	Authenticated bool
+}
+
+// Cache is the interface Resolver uses to store and look up answers.
+// The zero value of Resolver uses newShardedLRUCache as the default
+// implementation; callers may plug in their own (e.g. backed by redis)
+// as long as it honours Expires.
+type Cache interface {
+	Get(key CacheKey) (CacheEntry, bool)
+	Put(key CacheKey, entry CacheEntry)
+	Purge()
+}
+
+func normalizeCacheName(domain string) string {
+	if !strings.HasSuffix(domain, ".") {
+		domain = domain + "."
+	}
+	return strings.ToLower(domain)
+}
+
+// minTTL returns the smallest TTL across an RRset, which is what should
+// bound how long the whole answer is cached for (RFC 2181 section 5.2).
+func minTTL(resourceList []dnsmessage.Resource) time.Duration {
+	var min uint32
+	has := false
+	for _, one := range resourceList {
+		ttl := one.Header.TTL
+		if !has || ttl < min {
+			min = ttl
+			has = true
+		}
+	}
+	if !has {
+		return 0
+	}
+	return time.Duration(min) * time.Second
+}
+
+const shardCount = 16
+
+// shardedLRUCache is the default Cache implementation: entries are
+// distributed across a fixed number of shards (each guarded by its own
+// mutex) to reduce lock contention, and each shard evicts its least
+// recently used entry once it holds more than maxPerShard items.
+type shardedLRUCache struct {
+	shards      [shardCount]lruShard
+	maxPerShard int
+}
+
+type lruShard struct {
+	mu      sync.Mutex
+	entries map[CacheKey]*list.Element
+	order   *list.List // front = most recently used
+}
+
+type lruItem struct {
+	key   CacheKey
+	entry CacheEntry
+}
+
+func newShardedLRUCache(maxEntries int) *shardedLRUCache {
+	c := &shardedLRUCache{
+		maxPerShard: maxEntries / shardCount,
+	}
+	if c.maxPerShard < 1 {
+		c.maxPerShard = 1
+	}
+	for i := range c.shards {
+		c.shards[i].entries = make(map[CacheKey]*list.Element)
+		c.shards[i].order = list.New()
+	}
+	return c
+}
+
+func (c *shardedLRUCache) shardFor(key CacheKey) *lruShard {
+	h := fnv32(key.Name) ^ uint32(key.Type)
+	return &c.shards[h%shardCount]
+}
+
+func (c *shardedLRUCache) Get(key CacheKey) (CacheEntry, bool) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.entries[key]
+	if !ok {
+		return CacheEntry{}, false
+	}
+	item := elem.Value.(*lruItem)
+	if time.Now().After(item.entry.Expires) {
+		s.order.Remove(elem)
+		delete(s.entries, key)
+		return CacheEntry{}, false
+	}
+	s.order.MoveToFront(elem)
+	return item.entry, true
+}
+
+func (c *shardedLRUCache) Put(key CacheKey, entry CacheEntry) {
+	s := c.shardFor(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.entries[key]; ok {
+		elem.Value.(*lruItem).entry = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+	elem := s.order.PushFront(&lruItem{key: key, entry: entry})
+	s.entries[key] = elem
+
+	for s.order.Len() > c.maxPerShard {
+		oldest := s.order.Back()
+		if oldest == nil {
+			break
+		}
+		delete(s.entries, oldest.Value.(*lruItem).key)
+		s.order.Remove(oldest)
+	}
+}
+
+func (c *shardedLRUCache) Purge() {
+	for i := range c.shards {
+		s := &c.shards[i]
+		s.mu.Lock()
+		s.entries = make(map[CacheKey]*list.Element)
+		s.order = list.New()
+		s.mu.Unlock()
+	}
+}
+
+// fnv32 is a tiny string hash used to pick a shard; it doesn't need to
+// be cryptographically strong, only evenly distributed.
+func fnv32(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= prime32
+	}
+	return h
+}
+
+// cachedDelegation walks up from domain label by label looking for a
+// cached NS RRset, so iteration can resume from the closest known zone
+// instead of the root. Returns nil if nothing is cached.
+func (r *Resolver) cachedDelegation(domain string) (nsServerList []string) {
+	cache := r.cache()
+	name := normalizeCacheName(domain)
+	for {
+		idx := strings.Index(name, ".")
+		if idx < 0 || idx+1 >= len(name) {
+			return nil
+		}
+		name = name[idx+1:]
+		if name == "" {
+			return nil
+		}
+		entry, ok := cache.Get(CacheKey{Name: name, Type: dnsmessage.TypeNS})
+		if !ok || entry.Negative {
+			continue
+		}
+		for _, one := range entry.Answers {
+			nsResource, ok := one.Body.(*dnsmessage.NSResource)
+			if ok {
+				nsServerList = append(nsServerList, strings.TrimSuffix(nsResource.NS.String(), ".")+":53")
+			}
+		}
+		if len(nsServerList) > 0 {
+			return nsServerList
+		}
+	}
+}
+
+func (r *Resolver) cache() Cache {
+	if r.Cache != nil {
+		return r.Cache
+	}
+	return defaultCache
+}
+
+// defaultCache backs every Resolver that doesn't set its own Cache,
+// including DefaultResolver.
+var defaultCache = newShardedLRUCache(4096)
@@ -0,0 +1,74 @@
+package nslookup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestShardedLRUCacheTTLExpiry(t *testing.T) {
+	c := newShardedLRUCache(64)
+	key := CacheKey{Name: "example.com.", Type: dnsmessage.TypeA}
+
+	c.Put(key, CacheEntry{Expires: time.Now().Add(-time.Second)})
+	if _, ok := c.Get(key); ok {
+		t.Fatalf("Get returned an entry past its Expires time")
+	}
+	// the expired entry should also have been evicted, not just hidden
+	if _, ok := c.shardFor(key).entries[key]; ok {
+		t.Fatalf("expired entry was not removed from the shard")
+	}
+
+	c.Put(key, CacheEntry{Expires: time.Now().Add(time.Minute)})
+	if _, ok := c.Get(key); !ok {
+		t.Fatalf("Get missed an entry still within its Expires time")
+	}
+}
+
+func TestShardedLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	// maxEntries == shardCount makes maxPerShard 1, so every shard
+	// evicts down to its single most recently touched key.
+	c := newShardedLRUCache(shardCount)
+
+	keys := make([]CacheKey, 0, shardCount*3)
+	for i := 0; i < shardCount*3; i++ {
+		keys = append(keys, CacheKey{Name: fmt.Sprintf("host%d.example.com.", i), Type: dnsmessage.TypeA})
+	}
+	for _, key := range keys {
+		c.Put(key, CacheEntry{Expires: time.Now().Add(time.Minute)})
+	}
+
+	var present int
+	for _, key := range keys {
+		if _, ok := c.Get(key); ok {
+			present++
+		}
+	}
+	if present > shardCount {
+		t.Fatalf("cache holds %d entries, want at most %d (one per shard)", present, shardCount)
+	}
+
+	for i := range c.shards {
+		if n := c.shards[i].order.Len(); n > 1 {
+			t.Fatalf("shard %d holds %d entries, want at most 1", i, n)
+		}
+	}
+}
+
+func TestShardedLRUCachePutUpdatesExistingKey(t *testing.T) {
+	c := newShardedLRUCache(64)
+	key := CacheKey{Name: "example.com.", Type: dnsmessage.TypeA}
+
+	c.Put(key, CacheEntry{Negative: true, Expires: time.Now().Add(time.Minute)})
+	c.Put(key, CacheEntry{Negative: false, Expires: time.Now().Add(time.Minute)})
+
+	entry, ok := c.Get(key)
+	if !ok {
+		t.Fatalf("Get missed a freshly updated entry")
+	}
+	if entry.Negative {
+		t.Fatalf("Get returned the stale entry, want the updated one")
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/orestonce/nslookup"
+	"github.com/orestonce/nslookup/subenum"
 	"github.com/spf13/cobra"
+	"golang.org/x/time/rate"
 	"os"
 	"strings"
 )
@@ -18,7 +21,7 @@ func main() {
 
 func init() {
 	var lookupType string
-	root.Flags().StringVarP(&lookupType, "type", "t", "A", "查询类型[A, SOA, NS, TXT, CNAME, MX]")
+	root.Flags().StringVarP(&lookupType, "type", "t", "A", "查询类型[A, SOA, NS, TXT, CNAME, MX, PTR]")
 	root.Run = func(cmd *cobra.Command, args []string) {
 		if cmd.Flags().NArg() < 1 {
 			fmt.Println("nslookup 需要输入要查询的域名")
@@ -39,6 +42,8 @@ func init() {
 				fn = nslookup.LookupNS
 			case "TXT":
 				fn = nslookup.LookupTXT
+			case "PTR":
+				fn = nslookup.LookupAddr
 			}
 			if fn != nil {
 				var valueList []string
@@ -80,3 +85,55 @@ func init() {
 		}
 	}
 }
+
+func init() {
+	var wordlist string
+	var workers int
+	var resolvers []string
+	var ratePerSec float64
+
+	enumCmd := &cobra.Command{
+		Use:   "enum",
+		Short: "暴力枚举子域名",
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) < 1 {
+				fmt.Println("nslookup enum 需要输入要枚举的主域名")
+				os.Exit(-1)
+				return
+			}
+			f, err := os.Open(wordlist)
+			if err != nil {
+				fmt.Println("打开字典文件出错", err)
+				os.Exit(-1)
+				return
+			}
+			defer f.Close()
+
+			e := &subenum.Enumerator{
+				Base:      args[0],
+				Labels:    f,
+				Workers:   workers,
+				Resolvers: resolvers,
+				RateLimit: rate.Limit(ratePerSec),
+			}
+			for result := range e.Run(context.Background()) {
+				if result.Err != nil {
+					fmt.Println(result.Name, "查询出错", result.Err)
+					continue
+				}
+				if result.CNAME != "" {
+					fmt.Println(result.Name, "CNAME", result.CNAME)
+				}
+				for _, a := range result.A {
+					fmt.Println(result.Name, "A", a)
+				}
+			}
+		},
+	}
+	enumCmd.Flags().StringVar(&wordlist, "wordlist", "", "候选子域名字典文件路径")
+	enumCmd.Flags().IntVar(&workers, "workers", 20, "并发worker数量")
+	enumCmd.Flags().StringSliceVar(&resolvers, "resolvers", nil, "递归解析器地址列表(host:port), 轮询使用, 不填则从根服务器开始迭代查询")
+	enumCmd.Flags().Float64Var(&ratePerSec, "rate", 0, "每个worker每秒查询次数限制, 0表示不限制")
+	_ = enumCmd.MarkFlagRequired("wordlist")
+	root.AddCommand(enumCmd)
+}
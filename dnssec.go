@@ -0,0 +1,659 @@
+package nslookup
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DNSSECMode controls how hard a Resolver tries to validate DNSSEC
+// signatures, and what it does when validation fails.
+type DNSSECMode int
+
+const (
+	// DNSSECOff never requests or validates DNSSEC records.
+	DNSSECOff DNSSECMode = iota
+	// DNSSECOpportunistic sets the DO bit and validates when signatures
+	// are present, but returns unvalidated answers instead of an error
+	// when they are missing or broken.
+	DNSSECOpportunistic
+	// DNSSECStrict behaves like DNSSECOpportunistic but returns
+	// ErrBogus instead of an answer whenever validation fails.
+	DNSSECStrict
+)
+
+// ErrBogus is returned in DNSSECStrict mode when a response fails
+// DNSSEC validation (bad signature, broken chain of trust, or a
+// negative answer with no signed NSEC/NSEC3 record). Whether an NSEC or
+// NSEC3 record actually covers the queried name is not checked.
+var ErrBogus = errors.New("nslookup: response failed DNSSEC validation")
+
+// dnssecAlgo identifies the handful of signing algorithms this package
+// knows how to verify (RFC 8624 recommends RSASHA256 and
+// ECDSAP256SHA256 as the minimum interoperable set).
+type dnssecAlgo uint8
+
+const (
+	algoRSASHA256       dnssecAlgo = 8
+	algoECDSAP256SHA256 dnssecAlgo = 13
+)
+
+// TrustAnchor is a DS record a Resolver trusts to authenticate a zone's
+// DNSKEY set, normally the root zone's KSK.
+type TrustAnchor struct {
+	Zone       string // e.g. "."
+	KeyTag     uint16
+	Algorithm  dnssecAlgo
+	DigestType uint8
+	Digest     []byte
+}
+
+// defaultTrustAnchor is the IANA root zone KSK-2017 DS record
+// (https://data.iana.org/root-anchors/root-anchors.xml), used unless
+// Resolver.TrustAnchors is set explicitly.
+var defaultTrustAnchor = TrustAnchor{
+	Zone:       ".",
+	KeyTag:     20326,
+	Algorithm:  algoRSASHA256,
+	DigestType: 2, // SHA-256
+	Digest:     mustHex("E06D44B80B8F1D39A95C0B0D7C65D08458E880409BBC683457104237C7F8EC8D"),
+}
+
+func mustHex(s string) []byte {
+	b := make([]byte, len(s)/2)
+	for i := range b {
+		hi := hexNibble(s[i*2])
+		lo := hexNibble(s[i*2+1])
+		b[i] = hi<<4 | lo
+	}
+	return b
+}
+
+func hexNibble(c byte) byte {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0'
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10
+	}
+	return 0
+}
+
+// DNSSEC record types dnsmessage doesn't define constants for; it
+// parses them into UnknownResource and leaves the rdata for this
+// package to decode (RFC 4034 appendix A, RFC 5155 for NSEC3).
+const (
+	dnsTypeRRSIG  dnsmessage.Type = 46
+	dnsTypeNSEC   dnsmessage.Type = 47
+	dnsTypeDNSKEY dnsmessage.Type = 48
+	dnsTypeDS     dnsmessage.Type = 43
+	dnsTypeNSEC3  dnsmessage.Type = 50
+)
+
+// rawRRSIG, rawDNSKEY and rawDS hold the wire-format RDATA of record
+// types dnsmessage.Message doesn't model: it parses them into
+// UnknownResource, so this package decodes the bytes itself per their
+// respective RFCs (4034 appendix A).
+type rawRRSIG struct {
+	TypeCovered uint16
+	Algorithm   dnssecAlgo
+	Labels      uint8
+	OrigTTL     uint32
+	Expiration  uint32
+	Inception   uint32
+	KeyTag      uint16
+	SignerName  string
+	Signature   []byte
+}
+
+type rawDNSKEY struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm dnssecAlgo
+	PublicKey []byte
+	raw       []byte // flags+protocol+algorithm+publickey, for keytag/verify
+}
+
+type rawDS struct {
+	KeyTag     uint16
+	Algorithm  dnssecAlgo
+	DigestType uint8
+	Digest     []byte
+}
+
+func parseRRSIG(data []byte) (rawRRSIG, error) {
+	if len(data) < 18 {
+		return rawRRSIG{}, errors.New("nslookup: short RRSIG rdata")
+	}
+	sig := rawRRSIG{
+		TypeCovered: binary.BigEndian.Uint16(data[0:2]),
+		Algorithm:   dnssecAlgo(data[2]),
+		Labels:      data[3],
+		OrigTTL:     binary.BigEndian.Uint32(data[4:8]),
+		Expiration:  binary.BigEndian.Uint32(data[8:12]),
+		Inception:   binary.BigEndian.Uint32(data[12:16]),
+		KeyTag:      binary.BigEndian.Uint16(data[16:18]),
+	}
+	name, n, err := unpackDomainName(data, 18)
+	if err != nil {
+		return rawRRSIG{}, err
+	}
+	sig.SignerName = name
+	sig.Signature = data[n:]
+	return sig, nil
+}
+
+func parseDNSKEY(data []byte) (rawDNSKEY, error) {
+	if len(data) < 4 {
+		return rawDNSKEY{}, errors.New("nslookup: short DNSKEY rdata")
+	}
+	return rawDNSKEY{
+		Flags:     binary.BigEndian.Uint16(data[0:2]),
+		Protocol:  data[2],
+		Algorithm: dnssecAlgo(data[3]),
+		PublicKey: data[4:],
+		raw:       data,
+	}, nil
+}
+
+func parseDS(data []byte) (rawDS, error) {
+	if len(data) < 4 {
+		return rawDS{}, errors.New("nslookup: short DS rdata")
+	}
+	return rawDS{
+		KeyTag:     binary.BigEndian.Uint16(data[0:2]),
+		Algorithm:  dnssecAlgo(data[2]),
+		DigestType: data[3],
+		Digest:     data[4:],
+	}, nil
+}
+
+// unpackDomainName reads an uncompressed domain name out of an RRSIG's
+// signer-name field (RRSIG rdata never uses name compression).
+func unpackDomainName(data []byte, off int) (name string, next int, err error) {
+	var labels []string
+	for {
+		if off >= len(data) {
+			return "", 0, errors.New("nslookup: truncated name")
+		}
+		l := int(data[off])
+		off++
+		if l == 0 {
+			break
+		}
+		if off+l > len(data) {
+			return "", 0, errors.New("nslookup: truncated name label")
+		}
+		labels = append(labels, string(data[off:off+l]))
+		off += l
+	}
+	return strings.Join(labels, ".") + ".", off, nil
+}
+
+// keyTag implements the keytag algorithm from RFC 4034 appendix B.
+func keyTag(dnskeyRDATA []byte) uint16 {
+	var ac uint32
+	for i, b := range dnskeyRDATA {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+// dsDigest computes the DS digest for a DNSKEY owned by ownerName, per
+// RFC 4034 section 5.1.4.
+func dsDigest(ownerName string, dnskeyRDATA []byte, digestType uint8) ([]byte, error) {
+	buf := append(canonicalWireName(ownerName), dnskeyRDATA...)
+	switch digestType {
+	case 1:
+		sum := sha1.Sum(buf)
+		return sum[:], nil
+	case 2:
+		sum := sha256.Sum256(buf)
+		return sum[:], nil
+	default:
+		return nil, errors.New("nslookup: unsupported DS digest type")
+	}
+}
+
+// canonicalWireName lower-cases and wire-encodes name for use in
+// signature/digest input, per RFC 4034 section 6.2.
+func canonicalWireName(name string) []byte {
+	name = strings.ToLower(strings.TrimSuffix(name, ".")) + "."
+	if name == "." {
+		return []byte{0}
+	}
+	buf := bytes.NewBuffer(nil)
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		buf.WriteByte(byte(len(label)))
+		buf.WriteString(label)
+	}
+	buf.WriteByte(0)
+	return buf.Bytes()
+}
+
+// canonicalRRsetSignInput builds the data sig's signature actually
+// covers, per RFC 4035 section 5.3.2: sig's own RDATA up through the
+// canonical signer name (everything but the Signature field itself),
+// followed by every RR in the RRset in canonical form (RFC 4034
+// section 6.3: canonical owner name + type + class + sig's Original
+// TTL + rdlength + rdata, sorted by rdata).
+func canonicalRRsetSignInput(owner string, sig rawRRSIG, rdataList [][]byte) []byte {
+	sorted := make([][]byte, len(rdataList))
+	copy(sorted, rdataList)
+	sort.Slice(sorted, func(i, j int) bool { return bytes.Compare(sorted[i], sorted[j]) < 0 })
+
+	buf := bytes.NewBuffer(nil)
+	_ = binary.Write(buf, binary.BigEndian, sig.TypeCovered)
+	_ = binary.Write(buf, binary.BigEndian, uint8(sig.Algorithm))
+	_ = binary.Write(buf, binary.BigEndian, sig.Labels)
+	_ = binary.Write(buf, binary.BigEndian, sig.OrigTTL)
+	_ = binary.Write(buf, binary.BigEndian, sig.Expiration)
+	_ = binary.Write(buf, binary.BigEndian, sig.Inception)
+	_ = binary.Write(buf, binary.BigEndian, sig.KeyTag)
+	buf.Write(canonicalWireName(sig.SignerName))
+
+	ownerWire := canonicalWireName(owner)
+	for _, rdata := range sorted {
+		buf.Write(ownerWire)
+		_ = binary.Write(buf, binary.BigEndian, sig.TypeCovered)
+		_ = binary.Write(buf, binary.BigEndian, uint16(dnsmessage.ClassINET))
+		_ = binary.Write(buf, binary.BigEndian, sig.OrigTTL)
+		_ = binary.Write(buf, binary.BigEndian, uint16(len(rdata)))
+		buf.Write(rdata)
+	}
+	return buf.Bytes()
+}
+
+// verifyRRSIG checks sig's validity period and cryptographic signature
+// over signInput using key.
+func verifyRRSIG(sig rawRRSIG, key rawDNSKEY, signInput []byte, now time.Time) error {
+	if uint32(now.Unix()) > sig.Expiration || uint32(now.Unix()) < sig.Inception {
+		return errors.New("nslookup: RRSIG outside its validity period")
+	}
+	if key.Algorithm != sig.Algorithm {
+		return errors.New("nslookup: DNSKEY/RRSIG algorithm mismatch")
+	}
+	switch sig.Algorithm {
+	case algoRSASHA256:
+		pub, err := parseRSAPublicKey(key.PublicKey)
+		if err != nil {
+			return err
+		}
+		hashed := sha256.Sum256(signInput)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, hashed[:], sig.Signature)
+	case algoECDSAP256SHA256:
+		pub, err := parseECDSAP256PublicKey(key.PublicKey)
+		if err != nil {
+			return err
+		}
+		if len(sig.Signature) != 64 {
+			return errors.New("nslookup: bad ECDSA signature length")
+		}
+		r := new(big.Int).SetBytes(sig.Signature[:32])
+		s := new(big.Int).SetBytes(sig.Signature[32:])
+		hashed := sha256.Sum256(signInput)
+		if !ecdsa.Verify(pub, hashed[:], r, s) {
+			return errors.New("nslookup: ECDSA signature verification failed")
+		}
+		return nil
+	default:
+		return errors.New("nslookup: unsupported DNSSEC algorithm")
+	}
+}
+
+// verifyRRsetAgainstSigs tries every sig covering typeCovered and keyed
+// to key's tag, building the signing input from that sig's own OrigTTL
+// each time (RFC 4035 section 5.3.2 requires the RRSIG's Original TTL,
+// not whatever TTL the RRset happened to be served with).
+func verifyRRsetAgainstSigs(owner string, typeCovered uint16, rdataList [][]byte, sigs []rawRRSIG, key rawDNSKEY, now time.Time) error {
+	err := errors.New("nslookup: no RRSIG covers this RRset for the given key")
+	for _, sig := range sigs {
+		if sig.TypeCovered != typeCovered || sig.KeyTag != keyTag(key.raw) {
+			continue
+		}
+		signInput := canonicalRRsetSignInput(owner, sig, rdataList)
+		if err = verifyRRSIG(sig, key, signInput, now); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func parseRSAPublicKey(key []byte) (*rsa.PublicKey, error) {
+	if len(key) < 3 {
+		return nil, errors.New("nslookup: short RSA DNSKEY")
+	}
+	expLen := int(key[0])
+	off := 1
+	if expLen == 0 {
+		if len(key) < 3 {
+			return nil, errors.New("nslookup: short RSA DNSKEY exponent length")
+		}
+		expLen = int(key[1])<<8 | int(key[2])
+		off = 3
+	}
+	if off+expLen > len(key) {
+		return nil, errors.New("nslookup: truncated RSA DNSKEY")
+	}
+	e := new(big.Int).SetBytes(key[off : off+expLen])
+	n := new(big.Int).SetBytes(key[off+expLen:])
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func parseECDSAP256PublicKey(key []byte) (*ecdsa.PublicKey, error) {
+	if len(key) != 64 {
+		return nil, errors.New("nslookup: bad ECDSA P-256 DNSKEY length")
+	}
+	return &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(key[:32]),
+		Y:     new(big.Int).SetBytes(key[32:]),
+	}, nil
+}
+
+// trustAnchors returns the trust anchors a Resolver validates against.
+func (r *Resolver) trustAnchors() []TrustAnchor {
+	if len(r.TrustAnchors) > 0 {
+		return r.TrustAnchors
+	}
+	return []TrustAnchor{defaultTrustAnchor}
+}
+
+// anchorFor returns the trust anchor configured for zone exactly, if
+// any. It is checked at every zone dnssecChain.advance enters, not just
+// the root: a TrustAnchor whose Zone isn't "." lets a Resolver trust an
+// island of signed zones directly, without a DS chain from the root
+// down to it.
+func (r *Resolver) anchorFor(zone string) (TrustAnchor, bool) {
+	zone = normalizeCacheName(zone)
+	for _, anchor := range r.trustAnchors() {
+		if normalizeCacheName(anchor.Zone) == zone {
+			return anchor, true
+		}
+	}
+	return TrustAnchor{}, false
+}
+
+// dnssecChain tracks the chain of trust as lookupOnce walks referrals
+// down from the root: advance authenticates one zone's DNSKEY RRset,
+// descend authenticates the DS record a referral hands down for the
+// next zone, and validateRRset authenticates the final answer against
+// whichever zone's keys the walk last advanced into. Create one with
+// newDNSSECChain; ok is false until advance first succeeds.
+type dnssecChain struct {
+	r    *Resolver
+	zone string
+	keys []rawDNSKEY
+	ok   bool
+}
+
+func newDNSSECChain(r *Resolver) *dnssecChain {
+	return &dnssecChain{r: r}
+}
+
+// advance fetches zone's own DNSKEY RRset from serverList and
+// authenticates it: either a key in the set matches trustedDS (the DS
+// validated at the parent zone by descend) or zone itself has a
+// directly configured TrustAnchor. If neither holds, or the RRset's
+// RRSIG doesn't verify, the chain becomes unauthenticated for zone and
+// everything below it.
+func (c *dnssecChain) advance(ctx context.Context, zone string, serverList []string, trustedDS []rawDS) error {
+	c.ok = false
+	if anchor, found := c.r.anchorFor(zone); found {
+		trustedDS = append(trustedDS, rawDS{
+			KeyTag:     anchor.KeyTag,
+			Algorithm:  anchor.Algorithm,
+			DigestType: anchor.DigestType,
+			Digest:     anchor.Digest,
+		})
+	}
+	if len(trustedDS) == 0 {
+		return errors.New("nslookup: no trust anchor or validated parent DS for zone " + zone)
+	}
+
+	msg, err := c.r.rawExchange(ctx, serverList, zone, dnsTypeDNSKEY)
+	if err != nil {
+		return err
+	}
+	var keys []rawDNSKEY
+	var keyRDATA [][]byte
+	var sigs []rawRRSIG
+	for _, res := range msg.Answers {
+		unk, ok := res.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		switch res.Header.Type {
+		case dnsTypeDNSKEY:
+			key, err := parseDNSKEY(unk.Data)
+			if err == nil {
+				keys = append(keys, key)
+				keyRDATA = append(keyRDATA, unk.Data)
+			}
+		case dnsTypeRRSIG:
+			sig, err := parseRRSIG(unk.Data)
+			if err == nil && sig.TypeCovered == uint16(dnsTypeDNSKEY) {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return errors.New("nslookup: no DNSKEY found for zone " + zone)
+	}
+
+	var ksk *rawDNSKEY
+	for i := range keys {
+		tag := keyTag(keys[i].raw)
+		for _, ds := range trustedDS {
+			if tag != ds.KeyTag || keys[i].Algorithm != ds.Algorithm {
+				continue
+			}
+			digest, err := dsDigest(zone, keys[i].raw, ds.DigestType)
+			if err == nil && bytes.Equal(digest, ds.Digest) {
+				ksk = &keys[i]
+				break
+			}
+		}
+		if ksk != nil {
+			break
+		}
+	}
+	if ksk == nil {
+		return errors.New("nslookup: no DNSKEY in zone " + zone + " matches its trusted DS")
+	}
+	if err := verifyRRsetAgainstSigs(zone, uint16(dnsTypeDNSKEY), keyRDATA, sigs, *ksk, time.Now()); err != nil {
+		return err
+	}
+
+	c.zone = normalizeCacheName(zone)
+	c.keys = keys
+	c.ok = true
+	return nil
+}
+
+// descend authenticates the DS RRset a referral's Authority section
+// carries for childZone against the zone the chain currently trusts,
+// then calls advance to pull in and authenticate childZone's own
+// DNSKEY RRset. A referral with no DS record (or one that doesn't
+// verify) simply leaves the chain unauthenticated from childZone down,
+// unless childZone has its own configured TrustAnchor.
+func (c *dnssecChain) descend(ctx context.Context, childZone string, authorities []dnsmessage.Resource, childServers []string) error {
+	var dsList []rawDS
+	var dsRDATA [][]byte
+	var sigs []rawRRSIG
+	for _, res := range authorities {
+		unk, ok := res.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		switch res.Header.Type {
+		case dnsTypeDS:
+			ds, err := parseDS(unk.Data)
+			if err == nil {
+				dsList = append(dsList, ds)
+				dsRDATA = append(dsRDATA, unk.Data)
+			}
+		case dnsTypeRRSIG:
+			sig, err := parseRRSIG(unk.Data)
+			if err == nil && sig.TypeCovered == uint16(dnsTypeDS) {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+
+	var validatedDS []rawDS
+	if c.ok && len(dsList) > 0 {
+		for _, key := range c.keys {
+			if verifyRRsetAgainstSigs(childZone, uint16(dnsTypeDS), dsRDATA, sigs, key, time.Now()) == nil {
+				validatedDS = dsList
+				break
+			}
+		}
+	}
+	return c.advance(ctx, childZone, childServers, validatedDS)
+}
+
+// validateRRset authenticates receiveMsg.Answers (all owned by the same
+// name and of the same type) against the zone dnssecChain last advanced
+// into, i.e. the zone that actually answered the query. The covered type
+// is taken from the answer itself rather than the query type: a query
+// for, say, an A record can be answered by a sole CNAME RRset, which is
+// signed as type CNAME, not type A.
+func (c *dnssecChain) validateRRset(receiveMsg dnsmessage.Message) error {
+	if !c.ok {
+		return errors.New("nslookup: chain of trust did not reach the answering zone")
+	}
+	if len(receiveMsg.Answers) == 0 {
+		return errors.New("nslookup: nothing to authenticate")
+	}
+	owner := receiveMsg.Answers[0].Header.Name.String()
+	typeCovered := receiveMsg.Answers[0].Header.Type
+	rdataList := make([][]byte, 0, len(receiveMsg.Answers))
+	for _, a := range receiveMsg.Answers {
+		rdata, err := canonicalRDATA(a.Body)
+		if err != nil {
+			continue
+		}
+		rdataList = append(rdataList, rdata)
+	}
+
+	var sigs []rawRRSIG
+	extra := append(append([]dnsmessage.Resource{}, receiveMsg.Answers...), receiveMsg.Authorities...)
+	extra = append(extra, receiveMsg.Additionals...)
+	for _, res := range extra {
+		unk, ok := res.Body.(*dnsmessage.UnknownResource)
+		if ok && res.Header.Type == 46 { // RRSIG
+			sig, err := parseRRSIG(unk.Data)
+			if err == nil {
+				sigs = append(sigs, sig)
+			}
+		}
+	}
+
+	for _, key := range c.keys {
+		if verifyRRsetAgainstSigs(owner, uint16(typeCovered), rdataList, sigs, key, time.Now()) == nil {
+			return nil
+		}
+	}
+	return errors.New("nslookup: no valid RRSIG covers this RRset")
+}
+
+// hasDenialOfExistence reports whether authorities contains an
+// NSEC or NSEC3 record accompanied by its own RRSIG, the minimum bar
+// for a signed denial-of-existence proof. It does not decode the type
+// bitmap or walk the NSEC chain ordering; DNSSECStrict treats a proof
+// without even this much as bogus.
+func hasDenialOfExistence(authorities []dnsmessage.Resource) bool {
+	sawNSEC := false
+	sawRRSIGOverNSEC := false
+	for _, res := range authorities {
+		unk, ok := res.Body.(*dnsmessage.UnknownResource)
+		if !ok {
+			continue
+		}
+		switch res.Header.Type {
+		case dnsTypeNSEC, dnsTypeNSEC3:
+			sawNSEC = true
+		case dnsTypeRRSIG:
+			sig, err := parseRRSIG(unk.Data)
+			if err == nil && (sig.TypeCovered == uint16(dnsTypeNSEC) || sig.TypeCovered == uint16(dnsTypeNSEC3)) {
+				sawRRSIGOverNSEC = true
+			}
+		}
+	}
+	return sawNSEC && sawRRSIGOverNSEC
+}
+
+// canonicalRDATA re-encodes a resource body as RDATA in the canonical
+// wire form RFC 4034 section 6.2 requires for RRSIG verification
+// (embedded names lower-cased, uncompressed). dnsmessage.ResourceBody's
+// own pack method is unexported and may compress names, so this package
+// encodes the handful of types it supports itself.
+func canonicalRDATA(body dnsmessage.ResourceBody) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		buf.Write(b.A[:])
+	case *dnsmessage.AAAAResource:
+		buf.Write(b.AAAA[:])
+	case *dnsmessage.NSResource:
+		buf.Write(canonicalWireName(b.NS.String()))
+	case *dnsmessage.CNAMEResource:
+		buf.Write(canonicalWireName(b.CNAME.String()))
+	case *dnsmessage.PTRResource:
+		buf.Write(canonicalWireName(b.PTR.String()))
+	case *dnsmessage.MXResource:
+		_ = binary.Write(buf, binary.BigEndian, b.Pref)
+		buf.Write(canonicalWireName(b.MX.String()))
+	case *dnsmessage.SOAResource:
+		buf.Write(canonicalWireName(b.NS.String()))
+		buf.Write(canonicalWireName(b.MBox.String()))
+		_ = binary.Write(buf, binary.BigEndian, b.Serial)
+		_ = binary.Write(buf, binary.BigEndian, b.Refresh)
+		_ = binary.Write(buf, binary.BigEndian, b.Retry)
+		_ = binary.Write(buf, binary.BigEndian, b.Expire)
+		_ = binary.Write(buf, binary.BigEndian, b.MinTTL)
+	case *dnsmessage.TXTResource:
+		for _, s := range b.TXT {
+			buf.WriteByte(byte(len(s)))
+			buf.WriteString(s)
+		}
+	case *dnsmessage.SRVResource:
+		_ = binary.Write(buf, binary.BigEndian, b.Priority)
+		_ = binary.Write(buf, binary.BigEndian, b.Weight)
+		_ = binary.Write(buf, binary.BigEndian, b.Port)
+		buf.Write(canonicalWireName(b.Target.String()))
+	default:
+		return nil, errors.New("nslookup: no canonical RDATA encoder for this resource type")
+	}
+	return buf.Bytes(), nil
+}
+
+// edns0DNSSECOK is the DO bit, bit 15 of the extended RCODE/flags word
+// that EDNS0 stores in the OPT pseudo-RR's TTL field (RFC 3225). The
+// OPT record itself is built by Resolver.edns0OPT in edns0.go.
+const edns0DNSSECOK = 0x00008000
+
+// edns0DefaultUDPSize is the UDP payload size advertised in the OPT
+// record, matching common modern resolver defaults.
+const edns0DefaultUDPSize = 1232
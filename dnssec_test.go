@@ -0,0 +1,134 @@
+package nslookup
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TestKeyTagRSASHA256 checks keyTag (RFC 4034 appendix B) against a
+// fixed RSASHA256 DNSKEY RDATA, with the expected tag computed by an
+// independent implementation of the same additive checksum.
+func TestKeyTagRSASHA256(t *testing.T) {
+	key, err := parseDNSKEY(mustHex(rsaSHA256DNSKEYRDATAHex))
+	if err != nil {
+		t.Fatalf("parseDNSKEY: %v", err)
+	}
+	if tag := keyTag(key.raw); tag != 24446 {
+		t.Fatalf("keyTag = %d, want 24446", tag)
+	}
+}
+
+// rsaSHA256DNSKEYRDATAHex is an arbitrary but fixed DNSKEY RDATA (flags
+// 256/ZSK, protocol 3, algorithm 8/RSASHA256, a 32-byte "public key").
+const rsaSHA256DNSKEYRDATAHex = "01000308390c8c7d7247342cd8100f2f6f770d65d670e58e0351d8ae8e4f6eac342fc231"
+
+func TestParseDNSKEYRejectsShortRDATA(t *testing.T) {
+	if _, err := parseDNSKEY([]byte{1, 2, 3}); err == nil {
+		t.Fatalf("parseDNSKEY accepted 3-byte rdata, want an error")
+	}
+}
+
+func TestCanonicalRDATA_A(t *testing.T) {
+	body := &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}
+	got, err := canonicalRDATA(body)
+	if err != nil {
+		t.Fatalf("canonicalRDATA: %v", err)
+	}
+	want := []byte{192, 0, 2, 1}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("canonicalRDATA = %x, want %x", got, want)
+	}
+}
+
+func TestCanonicalRDATA_CNAMELowerCasesEmbeddedName(t *testing.T) {
+	name, err := dnsmessage.NewName("WWW.Example.COM.")
+	if err != nil {
+		t.Fatalf("NewName: %v", err)
+	}
+	body := &dnsmessage.CNAMEResource{CNAME: name}
+	got, err := canonicalRDATA(body)
+	if err != nil {
+		t.Fatalf("canonicalRDATA: %v", err)
+	}
+	want := canonicalWireName("www.example.com.")
+	if !bytes.Equal(got, want) {
+		t.Fatalf("canonicalRDATA = %x, want %x (lower-cased)", got, want)
+	}
+}
+
+func TestCanonicalRDATA_UnsupportedType(t *testing.T) {
+	if _, err := canonicalRDATA(&dnsmessage.OPTResource{}); err == nil {
+		t.Fatalf("canonicalRDATA accepted an OPT resource, want an error")
+	}
+}
+
+// rsaDNSKEYWireKey encodes pub as a DNSKEY public key field, per RFC
+// 3110: a one-byte exponent length (it always fits here), the exponent,
+// then the modulus.
+func rsaDNSKEYWireKey(pub *rsa.PublicKey) []byte {
+	e := big.NewInt(int64(pub.E)).Bytes()
+	buf := bytes.NewBuffer(nil)
+	buf.WriteByte(byte(len(e)))
+	buf.Write(e)
+	buf.Write(pub.N.Bytes())
+	return buf.Bytes()
+}
+
+// TestVerifyRRSIG_SignInputIncludesRRSIGRDATA signs a small RRset the
+// way a real authoritative server would (RFC 4035 section 5.3.2: hash
+// the RRSIG's own RDATA, through its canonical signer name, followed by
+// the canonicalized RRset) and checks verifyRRSIG/canonicalRRsetSignInput
+// accepts it — and rejects it once a covered RRSIG field (Labels) changes.
+func TestVerifyRRSIG_SignInputIncludesRRSIGRDATA(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	keyRDATA := append([]byte{0x01, 0x00, 3, byte(algoRSASHA256)}, rsaDNSKEYWireKey(&priv.PublicKey)...)
+	key, err := parseDNSKEY(keyRDATA)
+	if err != nil {
+		t.Fatalf("parseDNSKEY: %v", err)
+	}
+
+	sig := rawRRSIG{
+		TypeCovered: uint16(dnsmessage.TypeA),
+		Algorithm:   algoRSASHA256,
+		Labels:      3,
+		OrigTTL:     3600,
+		Expiration:  uint32(time.Now().Add(time.Hour).Unix()),
+		Inception:   uint32(time.Now().Add(-time.Hour).Unix()),
+		KeyTag:      keyTag(key.raw),
+		SignerName:  "example.com.",
+	}
+	rdataList := [][]byte{{192, 0, 2, 1}}
+	signInput := canonicalRRsetSignInput("www.example.com.", sig, rdataList)
+	hashed := sha256.Sum256(signInput)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.SHA256, hashed[:])
+	if err != nil {
+		t.Fatalf("SignPKCS1v15: %v", err)
+	}
+	sig.Signature = signature
+
+	if err := verifyRRSIG(sig, key, signInput, time.Now()); err != nil {
+		t.Fatalf("verifyRRSIG rejected a correctly constructed signature: %v", err)
+	}
+
+	// A signer builds signInput from its own RRSIG RDATA (labels here);
+	// a verifier that dropped that prefix (hashing only the RRset, the
+	// bug under test) would recompute the same hash regardless of
+	// Labels and wrongly accept this as still valid.
+	tampered := sig
+	tampered.Labels = 9
+	tamperedInput := canonicalRRsetSignInput("www.example.com.", tampered, rdataList)
+	if err := verifyRRSIG(tampered, key, tamperedInput, time.Now()); err == nil {
+		t.Fatalf("verifyRRSIG accepted a signature after the covered RRSIG RDATA changed")
+	}
+}
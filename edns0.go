@@ -0,0 +1,59 @@
+package nslookup
+
+import (
+	"context"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// defaultUDPPayloadSize is the EDNS0 buffer size advertised when
+// Resolver.UDPPayloadSize is unset, matching common modern resolver
+// defaults (large enough to fit most signed responses in one datagram).
+const defaultUDPPayloadSize = edns0DefaultUDPSize
+
+func (r *Resolver) udpPayloadSize() uint16 {
+	if r.UDPPayloadSize > 0 {
+		return r.UDPPayloadSize
+	}
+	return defaultUDPPayloadSize
+}
+
+// edns0OPT builds the OPT pseudo-record every query now carries,
+// advertising r's UDP buffer size and, when DNSSECMode requests it,
+// the DO bit (RFC 6891, RFC 3225).
+func (r *Resolver) edns0OPT() dnsmessage.Resource {
+	var ttl uint32
+	if r.DNSSECMode != DNSSECOff {
+		ttl = edns0DNSSECOK
+	}
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{
+			Name:  dnsmessage.MustNewName("."),
+			Class: dnsmessage.Class(r.udpPayloadSize()),
+			TTL:   ttl,
+		},
+		Body: &dnsmessage.OPTResource{},
+	}
+}
+
+// exchangeAuto sends send over r's configured transport and, when that
+// transport is UDP and the response comes back with the TC (truncated)
+// bit set, transparently retries the same query over TCP against the
+// same server list. This is the "UDP first, TCP on truncation" pattern
+// every modern recursive/stub resolver uses.
+func (r *Resolver) exchangeAuto(ctx context.Context, serverList []string, send []byte) (receive []byte, err error) {
+	receive, err = r.exchange(ctx, serverList, send)
+	if err != nil {
+		return nil, err
+	}
+	if r.Transport != TransportUDP {
+		return receive, nil
+	}
+	var msg dnsmessage.Message
+	if err := msg.Unpack(receive); err == nil && msg.Header.Truncated {
+		tcpResolver := *r
+		tcpResolver.Transport = TransportTCP
+		return tcpResolver.exchange(ctx, serverList, send)
+	}
+	return receive, nil
+}
@@ -0,0 +1,605 @@
+package nslookup
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// Transport selects which protocol a Resolver uses to talk to DNS servers.
+type Transport int
+
+const (
+	TransportUDP Transport = iota
+	TransportTCP
+	TransportTLS   // DNS-over-TLS (RFC 7858), default port 853
+	TransportHTTPS // DNS-over-HTTPS (RFC 8484), POST to DoHURL
+)
+
+// Resolver resolves DNS records. The zero value is usable: every unset
+// field falls back to the default documented on it below.
+//
+// Resolver mirrors the shape of net.Resolver: every lookup takes a
+// context.Context so callers running inside a server can enforce
+// timeouts and cancellation instead of relying on package-level globals.
+type Resolver struct {
+	// RootServers is the set of "host:port" servers iteration starts
+	// from. Defaults to the IANA root servers when nil.
+	RootServers []string
+
+	// DialTimeout bounds connecting to a single remote server.
+	DialTimeout time.Duration
+
+	// QueryTimeout bounds a single request/response exchange once
+	// connected (TCP/TLS read deadline, DoH request timeout). UDP
+	// exchanges use the fixed udpQueryTimeout instead.
+	QueryTimeout time.Duration
+
+	// Transport selects UDP, TCP, DNS-over-TLS or DNS-over-HTTPS.
+	// Defaults to TransportUDP, which also retries over TCP whenever a
+	// UDP response comes back truncated.
+	Transport Transport
+
+	// UDPPayloadSize is the buffer size advertised in the EDNS0 OPT
+	// record attached to every query. Defaults to 1232 bytes.
+	UDPPayloadSize uint16
+
+	// TLSConfig is used for TransportTLS and TransportHTTPS. May be nil
+	// for TransportHTTPS; TransportTLS passes it to tls.Client as is, so
+	// the handshake fails unless it sets ServerName or InsecureSkipVerify.
+	TLSConfig *tls.Config
+
+	// DoHURL is the DNS-over-HTTPS endpoint POSTed to when Transport is
+	// TransportHTTPS, e.g. "https://dns.google/dns-query".
+	DoHURL string
+
+	// Dial, if set, replaces the default dialer used to reach DNS
+	// servers for TransportUDP/TCP/TLS. network is "udp" or "tcp".
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Cache stores answers and NS delegations keyed by (qname, qtype),
+	// honouring each RRset's TTL. Defaults to a package-wide
+	// shardedLRUCache when nil.
+	Cache Cache
+
+	// DNSSECMode controls whether DNSSEC signatures are requested and
+	// validated. Defaults to DNSSECOff.
+	DNSSECMode DNSSECMode
+
+	// TrustAnchors authenticate the DNSKEY set of TrustAnchor.Zone.
+	// Defaults to the IANA root zone KSK when nil.
+	TrustAnchors []TrustAnchor
+
+	// Recursive sets the RD (recursion desired) bit on every query.
+	// Iterative resolution against authoritative servers (the default)
+	// must send RD=0; set this when RootServers actually points at
+	// recursive resolvers (e.g. 8.8.8.8) that need RD=1 to do any work.
+	Recursive bool
+
+	gLookupId uint32
+}
+
+// DefaultResolver is the Resolver used by the package-level Lookup*
+// functions. It queries port 53 of the IANA root servers over UDP
+// (falling back to TCP on truncation), with a 10 second dial timeout.
+var DefaultResolver = &Resolver{
+	RootServers:  gRootServers,
+	DialTimeout:  10 * time.Second,
+	QueryTimeout: 10 * time.Second,
+	Transport:    TransportUDP,
+}
+
+func (r *Resolver) rootServers() []string {
+	if len(r.RootServers) > 0 {
+		return r.RootServers
+	}
+	return gRootServers
+}
+
+func (r *Resolver) dialTimeout() time.Duration {
+	if r.DialTimeout > 0 {
+		return r.DialTimeout
+	}
+	return 10 * time.Second
+}
+
+func (r *Resolver) queryTimeout() time.Duration {
+	if r.QueryTimeout > 0 {
+		return r.QueryTimeout
+	}
+	return 10 * time.Second
+}
+
+func (r *Resolver) network() string {
+	if r.Transport == TransportUDP {
+		return "udp"
+	}
+	return "tcp"
+}
+
+// dialRemote races a dial against every address in targetAddrList and
+// keeps the first one that succeeds, closing any that connect later.
+func (r *Resolver) dialRemote(ctx context.Context, targetAddrList []string) (conn net.Conn, err error) {
+	if len(targetAddrList) == 0 {
+		return nil, errors.New("nslookup: dialRemote targetAddrList is nil")
+	}
+	ctx, cancelFn := context.WithTimeout(ctx, r.dialTimeout())
+	defer cancelFn()
+
+	dial := r.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	network := r.network()
+
+	wg := sync.WaitGroup{}
+	var errList []error
+	var locker sync.Mutex
+
+	for _, addr := range targetAddrList {
+		addr := addr
+		if r.Transport == TransportTLS {
+			addr = dotAddr(addr)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn0, err0 := dial(ctx, network, addr)
+			locker.Lock()
+			defer locker.Unlock()
+
+			if err0 != nil {
+				errList = append(errList, err0)
+				return
+			}
+			if conn != nil {
+				conn0.Close() // 同时有多个线程连接成功, 后面成功的就关闭丢弃
+				return
+			}
+			conn = conn0
+			cancelFn()
+		}()
+	}
+	wg.Wait()
+
+	if conn == nil {
+		return nil, errList[0]
+	}
+	if r.Transport == TransportTLS {
+		conn = tls.Client(conn, r.TLSConfig)
+	}
+	return conn, nil
+}
+
+// dotAddr rewrites addr's port to 853, the standard DNS-over-TLS port
+// (RFC 7858), since server lists are built for plain UDP/TCP on port 53
+// and servers rarely also listen there for TLS.
+func dotAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return net.JoinHostPort(addr, "853")
+	}
+	return net.JoinHostPort(host, "853")
+}
+
+// exchange sends send to one of targetAddrList and returns the raw
+// response bytes, using the transport configured on r.
+func (r *Resolver) exchange(ctx context.Context, targetAddrList []string, send []byte) (receive []byte, err error) {
+	if r.Transport == TransportHTTPS {
+		return r.exchangeDoH(ctx, send)
+	}
+
+	conn, err := r.dialRemote(ctx, targetAddrList)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if r.Transport == TransportUDP {
+		_ = conn.SetDeadline(time.Now().Add(udpQueryTimeout))
+		return exchangePacketUDP(conn, send)
+	}
+	_ = conn.SetDeadline(time.Now().Add(r.queryTimeout()))
+	return exchangePacket(conn, send)
+}
+
+// udpQueryTimeout bounds the single-datagram read a UDP exchange does;
+// a lost or dropped datagram fails fast rather than waiting out the
+// much longer connection-oriented QueryTimeout. A timeout is returned
+// as an error; exchangeAuto retries over TCP only on a truncated reply.
+const udpQueryTimeout = 2 * time.Second
+
+func (r *Resolver) exchangeDoH(ctx context.Context, send []byte) (receive []byte, err error) {
+	if r.DoHURL == "" {
+		return nil, errors.New("nslookup: Resolver.DoHURL is empty")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.DoHURL, bytes.NewReader(send))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := &http.Client{
+		Timeout: r.queryTimeout(),
+		Transport: &http.Transport{
+			TLSClientConfig: r.TLSConfig,
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	buf := bytes.NewBuffer(nil)
+	if _, err = buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exchangePacketUDP writes send as a single datagram with no length
+// prefix and reads a single datagram back, per RFC 1035 section 4.2.1.
+func exchangePacketUDP(conn net.Conn, send []byte) (receive []byte, err error) {
+	_, err = conn.Write(send)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 65535)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:n], nil
+}
+
+func (r *Resolver) nextID() uint16 {
+	return uint16(atomic.AddUint32(&r.gLookupId, 1))
+}
+
+// maxCNAMEChain bounds how many CNAME hops lookupResourceList will
+// follow before giving up with ErrMaxDepth.
+const maxCNAMEChain = 8
+
+// ErrCNAMELoop is returned when following a CNAME chain revisits a name
+// it has already seen.
+var ErrCNAMELoop = errors.New("nslookup: cname loop detected")
+
+// lookupResourceList resolves domain/typeC, transparently following
+// CNAME chains: if the answer section contains only CNAME records for
+// the queried name, it restarts resolution for the CNAME target with
+// the original typeC and concatenates every intermediate CNAME record
+// onto the final answer list, the same way a stub resolver would.
+func (r *Resolver) lookupResourceList(ctx context.Context, domain string, typeC dnsmessage.Type) (resourceList []dnsmessage.Resource, err error) {
+	seen := make(map[string]bool)
+	var chain []dnsmessage.Resource
+	for i := 0; ; i++ {
+		if i > maxCNAMEChain {
+			return nil, ErrMaxDepth
+		}
+		normalized := normalizeCacheName(domain)
+		if seen[normalized] {
+			return nil, ErrCNAMELoop
+		}
+		seen[normalized] = true
+
+		answers, err := r.lookupOnce(ctx, domain, typeC)
+		if err != nil {
+			return nil, err
+		}
+		if typeC == dnsmessage.TypeCNAME {
+			return append(chain, answers...), nil
+		}
+		target, ok := soleCNAMETarget(answers, normalized)
+		if !ok {
+			return append(chain, answers...), nil
+		}
+		chain = append(chain, answers...)
+		domain = target
+	}
+}
+
+// soleCNAMETarget reports whether answers consists entirely of CNAME
+// records for owner, and if so returns the last one's target name.
+func soleCNAMETarget(answers []dnsmessage.Resource, owner string) (target string, ok bool) {
+	if len(answers) == 0 {
+		return "", false
+	}
+	for _, answer := range answers {
+		cnameResource, isCNAME := answer.Body.(*dnsmessage.CNAMEResource)
+		if !isCNAME || normalizeCacheName(answer.Header.Name.String()) != owner {
+			return "", false
+		}
+		target = cnameResource.CNAME.String()
+	}
+	return target, target != ""
+}
+
+// rawExchange sends a single (domain, typeC) query to serverList and
+// returns the parsed response. It does no cache lookup, CNAME
+// following or referral walking; lookupOnce uses it for the per-hop
+// query, and dnssecChain uses it to fetch each zone's own DNSKEY RRset.
+func (r *Resolver) rawExchange(ctx context.Context, serverList []string, domain string, typeC dnsmessage.Type) (dnsmessage.Message, error) {
+	if !strings.HasSuffix(domain, ".") {
+		domain = domain + "."
+	}
+	name, err := dnsmessage.NewName(domain)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	msg := &dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:               r.nextID(),
+			RecursionDesired: r.Recursive,
+		},
+		Questions: []dnsmessage.Question{
+			{
+				Name:  name,
+				Type:  typeC,
+				Class: dnsmessage.ClassINET,
+			},
+		},
+	}
+	msg.Additionals = append(msg.Additionals, r.edns0OPT())
+	send, err := msg.Pack()
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	receive, err := r.exchangeAuto(ctx, serverList, send)
+	if err != nil {
+		return dnsmessage.Message{}, err
+	}
+	var receiveMsg dnsmessage.Message
+	if err := receiveMsg.Unpack(receive); err != nil {
+		return dnsmessage.Message{}, err
+	}
+	if err := validateResponse(msg, &receiveMsg); err != nil {
+		return dnsmessage.Message{}, err
+	}
+	return receiveMsg, nil
+}
+
+// validateResponse reports whether receiveMsg looks like a genuine
+// answer to query rather than a stray or spoofed datagram: its ID must
+// match and it must echo back the question asked. This matters most
+// over UDP, the default transport, where any process can send a
+// same-source-port datagram and have it accepted as the reply.
+func validateResponse(query *dnsmessage.Message, receiveMsg *dnsmessage.Message) error {
+	if receiveMsg.Header.ID != query.Header.ID {
+		return errors.New("nslookup: response ID does not match query ID")
+	}
+	if len(receiveMsg.Questions) != len(query.Questions) {
+		return errors.New("nslookup: response echoed the wrong number of questions")
+	}
+	for i, q := range query.Questions {
+		got := receiveMsg.Questions[i]
+		if !strings.EqualFold(got.Name.String(), q.Name.String()) || got.Type != q.Type || got.Class != q.Class {
+			return errors.New("nslookup: response did not echo the question asked")
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) lookupOnce(ctx context.Context, domain string, typeC dnsmessage.Type) (resourceList []dnsmessage.Resource, err error) {
+	if !strings.HasSuffix(domain, ".") {
+		domain = domain + "."
+	}
+	cacheKey := CacheKey{Name: normalizeCacheName(domain), Type: typeC}
+	if entry, ok := r.cache().Get(cacheKey); ok {
+		if entry.Negative {
+			return nil, ErrNotFound
+		}
+		return entry.Answers, nil
+	}
+
+	// DNSSEC validation needs to watch every zone cut from the root
+	// down so it can authenticate each zone's DNSKEY RRset in turn, so
+	// it forgoes the cachedDelegation shortcut below and always starts
+	// iteration at the root.
+	var chain *dnssecChain
+	serverList := r.rootServers()
+	if r.DNSSECMode == DNSSECOff {
+		if cached := r.cachedDelegation(domain); cached != nil {
+			serverList = cached
+		}
+	} else {
+		chain = newDNSSECChain(r)
+		if err := chain.advance(ctx, ".", serverList, nil); err != nil && r.DNSSECMode == DNSSECStrict {
+			return nil, ErrBogus
+		}
+	}
+
+	for depth := 0; ; depth++ {
+		if depth > 10 {
+			return nil, ErrMaxDepth
+		}
+		receiveMsg, err := r.rawExchange(ctx, serverList, domain, typeC)
+		if err != nil {
+			return nil, err
+		}
+		if len(receiveMsg.Answers) > 0 {
+			authenticated := false
+			if chain != nil {
+				if err := chain.validateRRset(receiveMsg); err != nil {
+					if r.DNSSECMode == DNSSECStrict {
+						return nil, ErrBogus
+					}
+				} else {
+					authenticated = true
+				}
+			}
+			r.cache().Put(cacheKey, CacheEntry{
+				Answers:       receiveMsg.Answers,
+				Authorities:   receiveMsg.Authorities,
+				Additionals:   receiveMsg.Additionals,
+				Expires:       time.Now().Add(minTTL(receiveMsg.Answers)),
+				Authenticated: authenticated,
+			})
+			return receiveMsg.Answers, nil
+		}
+		var nsList []string
+		for _, one := range receiveMsg.Authorities {
+			nsResource, ok := one.Body.(*dnsmessage.NSResource)
+			if ok {
+				nsList = append(nsList, strings.TrimSuffix(nsResource.NS.String(), ".")+":53")
+			}
+		}
+		if nsList == nil {
+			if r.DNSSECMode == DNSSECStrict && !hasDenialOfExistence(receiveMsg.Authorities) {
+				return nil, ErrBogus
+			}
+			r.cache().Put(cacheKey, CacheEntry{
+				Negative: true,
+				Expires:  time.Now().Add(negativeTTL),
+			})
+			return nil, ErrNotFound
+		}
+		childZone := zoneOf(receiveMsg.Authorities)
+		r.cache().Put(CacheKey{Name: normalizeCacheName(childZone), Type: dnsmessage.TypeNS}, CacheEntry{
+			Answers: receiveMsg.Authorities,
+			Expires: time.Now().Add(minTTL(receiveMsg.Authorities)),
+		})
+		if chain != nil {
+			if err := chain.descend(ctx, childZone, receiveMsg.Authorities, nsList); err != nil && r.DNSSECMode == DNSSECStrict {
+				return nil, ErrBogus
+			}
+		}
+		serverList = nsList
+	}
+}
+
+// zoneOf returns the owner name of the first NS record in authorities,
+// i.e. the zone the delegation applies to.
+func zoneOf(authorities []dnsmessage.Resource) string {
+	for _, one := range authorities {
+		if _, ok := one.Body.(*dnsmessage.NSResource); ok {
+			return one.Header.Name.String()
+		}
+	}
+	return ""
+}
+
+func (r *Resolver) LookupA(ctx context.Context, domain string) (aResourceList []string, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeA)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		aResource, ok := answer.Body.(*dnsmessage.AResource)
+		if !ok {
+			continue
+		}
+		aResourceList = append(aResourceList, net.IPv4(aResource.A[0], aResource.A[1], aResource.A[2], aResource.A[3]).String())
+	}
+	if len(aResourceList) == 0 {
+		return nil, ErrNotFound
+	}
+	return aResourceList, nil
+}
+
+func (r *Resolver) LookupSOA(ctx context.Context, domain string) (soaResourceList []string, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeSOA)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		soaResource, ok := answer.Body.(*dnsmessage.SOAResource)
+		if !ok {
+			continue
+		}
+		soaResourceList = append(soaResourceList, strings.TrimSuffix(soaResource.NS.String(), "."))
+	}
+	if len(soaResourceList) == 0 {
+		return nil, ErrNotFound
+	}
+	return soaResourceList, nil
+}
+
+func (r *Resolver) LookupNS(ctx context.Context, domain string) (nsResourceList []string, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		nsResource, ok := answer.Body.(*dnsmessage.NSResource)
+		if !ok {
+			continue
+		}
+		nsResourceList = append(nsResourceList, strings.TrimSuffix(nsResource.NS.String(), "."))
+	}
+	if len(nsResourceList) == 0 {
+		return nil, ErrNotFound
+	}
+	return nsResourceList, nil
+}
+
+func (r *Resolver) LookupTXT(ctx context.Context, domain string) (txtResourceList []string, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		txtResource, ok := answer.Body.(*dnsmessage.TXTResource)
+		if !ok {
+			continue
+		}
+		txtResourceList = append(txtResourceList, txtResource.TXT...)
+	}
+	if len(txtResourceList) == 0 {
+		return nil, ErrNotFound
+	}
+	return txtResourceList, nil
+}
+
+func (r *Resolver) LookupCNAME(ctx context.Context, domain string) (cname string, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeCNAME)
+	if err != nil {
+		return "", err
+	}
+	for _, answer := range answerList {
+		cnameResource, ok := answer.Body.(*dnsmessage.CNAMEResource)
+		if !ok {
+			continue
+		}
+		cname = cnameResource.CNAME.String()
+		if cname != `` {
+			break
+		}
+	}
+	if cname == "" {
+		return "", ErrNotFound
+	}
+	return cname, nil
+}
+
+func (r *Resolver) LookupMX(ctx context.Context, domain string) (mxResourceList []net.MX, err error) {
+	answerList, err := r.lookupResourceList(ctx, domain, dnsmessage.TypeMX)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		mxResource, ok := answer.Body.(*dnsmessage.MXResource)
+		if !ok {
+			continue
+		}
+		mxResourceList = append(mxResourceList, net.MX{
+			Host: mxResource.MX.String(),
+			Pref: mxResource.Pref,
+		})
+	}
+	if len(mxResourceList) == 0 {
+		return nil, ErrNotFound
+	}
+	sortMX(mxResourceList)
+	return mxResourceList, nil
+}
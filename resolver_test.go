@@ -0,0 +1,66 @@
+package nslookup
+
+import (
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestDotAddrRewritesPortTo853(t *testing.T) {
+	cases := []struct {
+		name string
+		addr string
+		want string
+	}{
+		{name: "host and port 53", addr: "1.1.1.1:53", want: "1.1.1.1:853"},
+		{name: "ipv6 host and port", addr: "[2606:4700:4700::1111]:53", want: "[2606:4700:4700::1111]:853"},
+		{name: "host without a port", addr: "1.1.1.1", want: "1.1.1.1:853"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := dotAddr(tc.addr); got != tc.want {
+				t.Fatalf("dotAddr(%q) = %q, want %q", tc.addr, got, tc.want)
+			}
+		})
+	}
+}
+
+func mustName(t *testing.T, s string) dnsmessage.Name {
+	t.Helper()
+	name, err := dnsmessage.NewName(s)
+	if err != nil {
+		t.Fatalf("NewName(%q): %v", s, err)
+	}
+	return name
+}
+
+func TestValidateResponse(t *testing.T) {
+	query := &dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 42},
+		Questions: []dnsmessage.Question{{Name: mustName(t, "example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}
+
+	t.Run("matching id and question is accepted", func(t *testing.T) {
+		receive := &dnsmessage.Message{Header: dnsmessage.Header{ID: 42}, Questions: query.Questions}
+		if err := validateResponse(query, receive); err != nil {
+			t.Fatalf("validateResponse: %v", err)
+		}
+	})
+
+	t.Run("mismatched id is rejected", func(t *testing.T) {
+		receive := &dnsmessage.Message{Header: dnsmessage.Header{ID: 43}, Questions: query.Questions}
+		if err := validateResponse(query, receive); err == nil {
+			t.Fatalf("validateResponse accepted a mismatched response ID")
+		}
+	})
+
+	t.Run("mismatched question is rejected", func(t *testing.T) {
+		receive := &dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: 42},
+			Questions: []dnsmessage.Question{{Name: mustName(t, "attacker.example."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+		}
+		if err := validateResponse(query, receive); err == nil {
+			t.Fatalf("validateResponse accepted a response echoing the wrong question")
+		}
+	})
+}
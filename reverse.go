@@ -0,0 +1,64 @@
+package nslookup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// LookupAddr performs a reverse DNS lookup: ip may be a plain IPv4/IPv6
+// address (e.g. "1.2.3.4") or an already-formatted ARPA name (e.g.
+// "4.3.2.1.in-addr.arpa."); it returns the list of hostnames pointing
+// at it, trailing dot trimmed.
+func LookupAddr(ip string) (nameList []string, err error) {
+	return DefaultResolver.LookupAddr(context.Background(), ip)
+}
+
+func (r *Resolver) LookupAddr(ctx context.Context, ip string) (nameList []string, err error) {
+	arpaName, err := reverseARPAName(ip)
+	if err != nil {
+		return nil, err
+	}
+	answerList, err := r.lookupResourceList(ctx, arpaName, dnsmessage.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+	for _, answer := range answerList {
+		ptrResource, ok := answer.Body.(*dnsmessage.PTRResource)
+		if !ok {
+			continue
+		}
+		nameList = append(nameList, strings.TrimSuffix(ptrResource.PTR.String(), "."))
+	}
+	if len(nameList) == 0 {
+		return nil, ErrNotFound
+	}
+	return nameList, nil
+}
+
+// reverseARPAName builds the "*.in-addr.arpa." (IPv4) or nibble-reversed
+// "*.ip6.arpa." (IPv6) name to query for ip, per RFC 1035 section 3.5
+// and RFC 3596 section 2.5. If ip is already an ARPA name it is
+// returned unchanged.
+func reverseARPAName(ip string) (string, error) {
+	if strings.HasSuffix(strings.ToLower(strings.TrimSuffix(ip, ".")), ".arpa") {
+		return ip, nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "", errors.New("nslookup: invalid IP address: " + ip)
+	}
+	if v4 := parsed.To4(); v4 != nil && strings.Count(ip, ":") == 0 {
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa.", v4[3], v4[2], v4[1], v4[0]), nil
+	}
+	v6 := parsed.To16()
+	labels := make([]string, 0, 32)
+	for i := len(v6) - 1; i >= 0; i-- {
+		labels = append(labels, fmt.Sprintf("%x", v6[i]&0x0f), fmt.Sprintf("%x", v6[i]>>4))
+	}
+	return strings.Join(labels, ".") + ".ip6.arpa.", nil
+}
@@ -0,0 +1,49 @@
+package nslookup
+
+import "testing"
+
+func TestReverseARPAName(t *testing.T) {
+	cases := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{
+			name: "ipv4",
+			ip:   "1.2.3.4",
+			want: "4.3.2.1.in-addr.arpa.",
+		},
+		{
+			name: "ipv6",
+			ip:   "2001:db8::1",
+			want: "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.",
+		},
+		{
+			name: "already an arpa name is returned unchanged",
+			ip:   "4.3.2.1.in-addr.arpa.",
+			want: "4.3.2.1.in-addr.arpa.",
+		},
+		{
+			name: "arpa name without a trailing dot is still recognised",
+			ip:   "1.0.0.127.in-addr.arpa",
+			want: "1.0.0.127.in-addr.arpa",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := reverseARPAName(tc.ip)
+			if err != nil {
+				t.Fatalf("reverseARPAName(%q): %v", tc.ip, err)
+			}
+			if got != tc.want {
+				t.Fatalf("reverseARPAName(%q) = %q, want %q", tc.ip, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReverseARPANameRejectsInvalidIP(t *testing.T) {
+	if _, err := reverseARPAName("not-an-ip"); err == nil {
+		t.Fatalf("reverseARPAName accepted an invalid IP, want an error")
+	}
+}
@@ -0,0 +1,218 @@
+// Package subenum does dictionary-based subdomain discovery: given a
+// base domain and a wordlist of candidate labels, it tries
+// "<label>.<base>" for every label across a pool of workers and streams
+// whatever resolves.
+package subenum
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/orestonce/nslookup"
+	"golang.org/x/time/rate"
+)
+
+// Result is one candidate label's outcome, streamed from Enumerator.Run.
+type Result struct {
+	Name  string   // full name tried, e.g. "www.example.com."
+	A     []string // A records found, set when the name resolves
+	CNAME string   // CNAME target, set when the name is an alias
+	Err   error    // non-nil for lookup failures other than nslookup.ErrNotFound
+}
+
+// Enumerator brute-forces subdomains of Base by trying every label read
+// from Labels. The zero value is usable: it defaults to 20 workers, no
+// rate limiting and iterative resolution from the root.
+type Enumerator struct {
+	Base   string
+	Labels io.Reader
+
+	// Workers is how many labels are looked up concurrently. Defaults to 20.
+	Workers int
+
+	// RateLimit caps queries/sec per worker. Zero means unlimited.
+	RateLimit rate.Limit
+	// Burst is the token bucket burst size for RateLimit. Defaults to 1.
+	Burst int
+
+	// Resolvers, when non-empty, are recursive "host:port" resolvers
+	// queries round-robin across instead of iterating from the root.
+	Resolvers []string
+	// Resolver supplies the base timeouts/transport every query clones;
+	// defaults to a zero-value *nslookup.Resolver.
+	Resolver *nslookup.Resolver
+}
+
+func (e *Enumerator) workers() int {
+	if e.Workers > 0 {
+		return e.Workers
+	}
+	return 20
+}
+
+func (e *Enumerator) burst() int {
+	if e.Burst > 0 {
+		return e.Burst
+	}
+	return 1
+}
+
+func (e *Enumerator) baseResolver() *nslookup.Resolver {
+	if e.Resolver != nil {
+		return e.Resolver
+	}
+	return &nslookup.Resolver{}
+}
+
+// resolverFor returns the Resolver the idx'th query should use, round
+// robining across e.Resolvers when they're configured. e.Resolvers are
+// recursive resolvers, not authoritative servers to iterate against, so
+// queries against them must set RD=1 or they won't do any resolving.
+func (e *Enumerator) resolverFor(idx uint64) *nslookup.Resolver {
+	clone := *e.baseResolver()
+	if len(e.Resolvers) > 0 {
+		clone.RootServers = []string{e.Resolvers[idx%uint64(len(e.Resolvers))]}
+		clone.Recursive = true
+	}
+	return &clone
+}
+
+func fqdn(label, base string) string {
+	return label + "." + strings.TrimSuffix(base, ".") + "."
+}
+
+// Run starts Enumerator.workers() goroutines against each label read
+// from Labels and streams results on the returned channel, which is
+// closed once every label has been tried (or ctx is done). Names that
+// don't resolve at all (nslookup.ErrNotFound) are not sent.
+func (e *Enumerator) Run(ctx context.Context) <-chan Result {
+	out := make(chan Result)
+
+	go func() {
+		defer close(out)
+
+		wildcard, err := e.detectWildcard(ctx)
+		if err != nil {
+			select {
+			case out <- Result{Name: e.Base, Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		labels := make(chan string)
+		go func() {
+			defer close(labels)
+			scanner := bufio.NewScanner(e.Labels)
+			for scanner.Scan() {
+				label := strings.TrimSpace(scanner.Text())
+				if label == "" {
+					continue
+				}
+				select {
+				case labels <- label:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var resolverSeq uint64
+		var wg sync.WaitGroup
+		for i := 0; i < e.workers(); i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				limiter := e.newLimiter()
+				for label := range labels {
+					if limiter != nil {
+						if err := limiter.Wait(ctx); err != nil {
+							return
+						}
+					}
+					result := e.lookup(ctx, fqdn(label, e.Base), atomic.AddUint64(&resolverSeq, 1))
+					if result.Err == nslookup.ErrNotFound || wildcard.suppress(result) {
+						continue
+					}
+					select {
+					case out <- result:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	}()
+
+	return out
+}
+
+func (e *Enumerator) newLimiter() *rate.Limiter {
+	if e.RateLimit <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(e.RateLimit, e.burst())
+}
+
+func (e *Enumerator) lookup(ctx context.Context, name string, resolverIdx uint64) Result {
+	resolver := e.resolverFor(resolverIdx)
+
+	aList, err := resolver.LookupA(ctx, name)
+	if err == nil {
+		return Result{Name: name, A: aList}
+	}
+	if err != nslookup.ErrNotFound {
+		return Result{Name: name, Err: err}
+	}
+	cname, err := resolver.LookupCNAME(ctx, name)
+	if err == nil {
+		return Result{Name: name, CNAME: cname}
+	}
+	return Result{Name: name, Err: err}
+}
+
+// wildcardSet is the A set returned for a random label, used to
+// suppress enumerated results that are really just wildcard DNS
+// ("*.example.com") rather than a genuine subdomain.
+type wildcardSet map[string]bool
+
+func (w wildcardSet) suppress(r Result) bool {
+	if len(w) == 0 || r.Err != nil || len(r.A) == 0 {
+		return false
+	}
+	for _, a := range r.A {
+		if !w[a] {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Enumerator) detectWildcard(ctx context.Context) (wildcardSet, error) {
+	name := fqdn(randomLabel(16), e.Base)
+	aList, err := e.resolverFor(0).LookupA(ctx, name)
+	if err == nslookup.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	set := make(wildcardSet, len(aList))
+	for _, a := range aList {
+		set[a] = true
+	}
+	return set, nil
+}
+
+func randomLabel(n int) string {
+	buf := make([]byte, (n+1)/2)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)[:n]
+}